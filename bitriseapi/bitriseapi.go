@@ -0,0 +1,94 @@
+// Package bitriseapi wraps the subset of the Bitrise.io API used to
+// trigger builds from incoming webhooks.
+package bitriseapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// TriggerAPIParamsModel is the payload sent to Bitrise's build trigger
+// endpoint for a single buildable event (a push, a tag, a pull request, ...).
+type TriggerAPIParamsModel struct {
+	BuildParams  BuildParamsModel       `json:"build_params"`
+	TriggeredBy  string                 `json:"triggered_by,omitempty"`
+	Environments map[string]interface{} `json:"environments,omitempty"`
+}
+
+// BuildParamsModel describes the git ref / commit that should be built.
+type BuildParamsModel struct {
+	Branch                 string `json:"branch,omitempty"`
+	Tag                    string `json:"tag,omitempty"`
+	CommitHash             string `json:"commit_hash,omitempty"`
+	CommitMessage          string `json:"commit_message,omitempty"`
+	PullRequestID          int    `json:"pull_request_id,omitempty"`
+	BaseRepositoryURL      string `json:"base_repository_url,omitempty"`
+	HeadRepositoryURL      string `json:"head_repository_url,omitempty"`
+	PullRequestHeadBranch  string `json:"pull_request_head_branch,omitempty"`
+	PullRequestMergeBranch string `json:"pull_request_merge_branch,omitempty"`
+}
+
+// Error is returned by TriggerBuild when Bitrise responds with a 4xx or 5xx
+// status, or when the request fails at the network level (StatusCode is
+// then 0). Retryable reports whether the failure is transient (5xx,
+// network-level) as opposed to permanent (4xx) so callers can decide
+// whether retrying is worthwhile.
+type Error struct {
+	StatusCode int
+	Retryable  bool
+	msg        string
+}
+
+func (e *Error) Error() string { return e.msg }
+
+// BuildTriggerURL builds the Bitrise build trigger URL for the given app.
+func BuildTriggerURL(baseURL string, appSlug string) (*url.URL, error) {
+	if appSlug == "" {
+		return nil, fmt.Errorf("No App Slug provided")
+	}
+	return url.Parse(fmt.Sprintf("%s/app/%s/build/start.json", baseURL, appSlug))
+}
+
+// TriggerBuild calls the Bitrise build trigger API. When isOnlyLog is true
+// no HTTP request is performed; the call is logged and treated as
+// successful instead, which is how the local / test environments avoid
+// triggering real builds.
+func TriggerBuild(triggerURL *url.URL, apiToken string, params TriggerAPIParamsModel, isOnlyLog bool) (*http.Response, error) {
+	reqBody, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal trigger params: %s", err)
+	}
+
+	if isOnlyLog {
+		log.Printf(" (debug) isOnlyLog: true, skipping the build trigger request to %s with params: %s", triggerURL, reqBody)
+		return nil, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, triggerURL.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", apiToken))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Network-level failures (timeouts, connection resets, DNS, ...)
+		// are always worth retrying.
+		return nil, &Error{Retryable: true, msg: fmt.Sprintf("Failed to perform request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return resp, &Error{StatusCode: resp.StatusCode, Retryable: true, msg: fmt.Sprintf("Bitrise API responded with server error: %s", resp.Status)}
+	}
+	if resp.StatusCode >= 400 {
+		return resp, &Error{StatusCode: resp.StatusCode, Retryable: false, msg: fmt.Sprintf("Bitrise API responded with client error: %s", resp.Status)}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+// Package config provides process-wide configuration, populated from
+// environment variables at startup.
+package config
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ServerEnvMode ...
+type ServerEnvMode string
+
+// ServerEnvModeProd, ServerEnvModeDev, ...
+const (
+	ServerEnvModeProd ServerEnvMode = "prod"
+	ServerEnvModeDev  ServerEnvMode = "dev"
+)
+
+// SendRequestToURL - if defined, every outgoing trigger request is sent to
+// this URL instead of the real Bitrise API. Intended for test mode.
+var SendRequestToURL *url.URL
+
+// GetServerEnvMode returns the configured server environment mode.
+func GetServerEnvMode() ServerEnvMode {
+	if os.Getenv("SERVER_ENV_MODE") == string(ServerEnvModeProd) {
+		return ServerEnvModeProd
+	}
+	return ServerEnvModeDev
+}
+
+// AsyncTriggerEnabled controls whether incoming webhooks are queued and
+// processed by the worker pool (true) or triggered synchronously inline
+// with the webhook request (false, the legacy behavior).
+//
+// Defaults to false so that existing deployments, and the SendRequestToURL
+// test mode, keep their current synchronous semantics until operators
+// opt in.
+func AsyncTriggerEnabled() bool {
+	return getBoolEnv("ASYNC_TRIGGER_ENABLED", false)
+}
+
+// WorkerPoolSize is the number of worker goroutines draining the build
+// trigger queue when AsyncTriggerEnabled is true.
+func WorkerPoolSize() int {
+	return getIntEnv("WORKER_POOL_SIZE", 4)
+}
+
+// MaxTriggerAttempts is the number of attempts (including the first one)
+// a queued build trigger job gets before it's moved to the dead-letter
+// queue.
+func MaxTriggerAttempts() int {
+	return getIntEnv("MAX_TRIGGER_ATTEMPTS", 5)
+}
+
+// QueueBackend selects which queue.Queue implementation backs the
+// asynchronous build-trigger worker pool: "memory" (default, the
+// process-local ring used by tests and single-instance dev setups) or
+// "bolt" (durable, survives a restart; see QueueBoltPath).
+func QueueBackend() string {
+	return getStringEnv("QUEUE_BACKEND", "memory")
+}
+
+// QueueBoltPath is the BoltDB file path used when QueueBackend is "bolt".
+func QueueBoltPath() string {
+	return getStringEnv("QUEUE_BOLT_PATH", "./bitrise-webhooks.queue.db")
+}
+
+// DeadLetterBoltPath is the BoltDB file path used for the dead-letter
+// store when QueueBackend is "bolt", so dead-lettered jobs survive a
+// restart alongside the durable queue they came from.
+func DeadLetterBoltPath() string {
+	return getStringEnv("DEAD_LETTER_BOLT_PATH", "./bitrise-webhooks.deadletter.db")
+}
+
+// IdempotencyBackend selects which idempotency.Cache implementation
+// backs delivery-ID replay protection: "memory" (default, process-local
+// LRU; see IdempotencyLRUCapacity) or "redis" (shared across instances;
+// see IdempotencyRedisAddr).
+func IdempotencyBackend() string {
+	return getStringEnv("IDEMPOTENCY_BACKEND", "memory")
+}
+
+// IdempotencyLRUCapacity is the maximum number of entries the in-memory
+// idempotency cache holds when IdempotencyBackend is "memory".
+func IdempotencyLRUCapacity() int {
+	return getIntEnv("IDEMPOTENCY_LRU_CAPACITY", 10000)
+}
+
+// IdempotencyRedisAddr is the Redis server address used when
+// IdempotencyBackend is "redis".
+func IdempotencyRedisAddr() string {
+	return getStringEnv("IDEMPOTENCY_REDIS_ADDR", "localhost:6379")
+}
+
+// IdempotencyTTL is how long HTTPHandler remembers a delivery's response
+// and replays it instead of re-processing a retried webhook, keyed by
+// (service-id, app-slug, delivery-id).
+func IdempotencyTTL() time.Duration {
+	return getDurationEnv("IDEMPOTENCY_TTL_SECONDS", 10*time.Minute)
+}
+
+// ReplaySkewWindow is how old a delivery's timestamp (for providers that
+// send one) is allowed to be before HTTPHandler rejects it outright, to
+// blunt replay attacks against the signature-verification feature.
+func ReplaySkewWindow() time.Duration {
+	return getDurationEnv("REPLAY_SKEW_WINDOW_SECONDS", 5*time.Minute)
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil {
+		return defaultValue
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+func getStringEnv(key string, defaultValue string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// WebhookSecretStore resolves the signing secret for a given
+// (service-id, app-slug) pair, so HTTPHandler can verify inbound webhook
+// signatures before trusting the payload.
+type WebhookSecretStore interface {
+	// Lookup returns the secret configured for serviceID/appSlug, and
+	// whether one was found at all.
+	Lookup(serviceID, appSlug string) (secret string, found bool)
+}
+
+// SecretStore is the process-wide WebhookSecretStore. Defaults to
+// EnvSecretStore, the simplest option for single-app deployments.
+var SecretStore WebhookSecretStore = EnvSecretStore{}
+
+// RequireSignedWebhooks, when true, makes HTTPHandler reject any provider
+// that doesn't have a secret registered in SecretStore, so operators can
+// enforce signing fleet-wide instead of relying on every app owner to
+// configure one.
+func RequireSignedWebhooks() bool {
+	return getBoolEnv("REQUIRE_SIGNED_WEBHOOKS", false)
+}
+
+// EnvSecretStore looks up secrets from environment variables named
+// WEBHOOK_SECRET__<SERVICE_ID>__<APP_SLUG>, with '-' mapped to '_'.
+type EnvSecretStore struct{}
+
+// Lookup implements WebhookSecretStore.Lookup.
+func (EnvSecretStore) Lookup(serviceID, appSlug string) (string, bool) {
+	key := envSecretKey(serviceID, appSlug)
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+func envSecretKey(serviceID, appSlug string) string {
+	norm := func(s string) string { return strings.ToUpper(strings.ReplaceAll(s, "-", "_")) }
+	return fmt.Sprintf("WEBHOOK_SECRET__%s__%s", norm(serviceID), norm(appSlug))
+}
+
+// FileSecretStore reads "service-id/app-slug secret" lines from a file
+// once at construction time. Intended for deployments that'd rather ship
+// a mounted secrets file than set one env var per app.
+type FileSecretStore struct {
+	secrets map[string]string
+}
+
+// NewFileSecretStore reads path and builds a FileSecretStore from it.
+func NewFileSecretStore(path string) (*FileSecretStore, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read webhook secrets file: %s", err)
+	}
+
+	secrets := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Malformed webhook secrets line: %q", line)
+		}
+		secrets[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to parse webhook secrets file: %s", err)
+	}
+
+	return &FileSecretStore{secrets: secrets}, nil
+}
+
+// Lookup implements WebhookSecretStore.Lookup.
+func (s *FileSecretStore) Lookup(serviceID, appSlug string) (string, bool) {
+	secret, ok := s.secrets[serviceID+"/"+appSlug]
+	return secret, ok
+}
+
+// HTTPSecretStore resolves secrets by calling out to an external HTTP
+// endpoint, for operators who keep app secrets in a separate service
+// (e.g. a secrets manager fronted by an internal API) rather than baking
+// them into this service's environment or filesystem.
+type HTTPSecretStore struct {
+	// BaseURL is queried as BaseURL + "/{service-id}/{app-slug}"; a 200
+	// response body, with surrounding whitespace trimmed, is used as the
+	// secret. Any other status, or an empty body, means "not found".
+	BaseURL string
+	Client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewHTTPSecretStore creates an HTTPSecretStore querying baseURL.
+func NewHTTPSecretStore(baseURL string) *HTTPSecretStore {
+	return &HTTPSecretStore{
+		BaseURL: baseURL,
+		Client:  http.DefaultClient,
+		cache:   map[string]string{},
+	}
+}
+
+// Lookup implements WebhookSecretStore.Lookup.
+func (s *HTTPSecretStore) Lookup(serviceID, appSlug string) (string, bool) {
+	cacheKey := serviceID + "/" + appSlug
+
+	s.mu.Lock()
+	if secret, ok := s.cache[cacheKey]; ok {
+		s.mu.Unlock()
+		return secret, true
+	}
+	s.mu.Unlock()
+
+	resp, err := s.Client.Get(fmt.Sprintf("%s/%s", strings.TrimRight(s.BaseURL, "/"), cacheKey))
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", false
+	}
+	secret := strings.TrimSpace(string(body))
+	if secret == "" {
+		return "", false
+	}
+
+	s.mu.Lock()
+	s.cache[cacheKey] = secret
+	s.mu.Unlock()
+
+	return secret, true
+}
@@ -0,0 +1,15 @@
+// Package metrics provides lightweight tracing helpers used to time and
+// log named sections of the request handling pipeline.
+package metrics
+
+import (
+	"log"
+	"time"
+)
+
+// Trace runs fn and logs how long it took, tagged with name.
+func Trace(name string, fn func()) {
+	startTime := time.Now()
+	fn()
+	log.Printf(" (i) [Metrics] %s took: %s", name, time.Since(startTime))
+}
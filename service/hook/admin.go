@@ -0,0 +1,80 @@
+package hook
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/service"
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+	"github.com/gorilla/mux"
+)
+
+// ProvidersListRespModel ...
+type ProvidersListRespModel struct {
+	Providers []string `json:"providers"`
+}
+
+// ProvidersListHandler handles GET /providers, listing every webhook
+// provider currently registered via hookCommon.RegisterProvider.
+func ProvidersListHandler(w http.ResponseWriter, r *http.Request) {
+	service.RespondWithSuccess(w, ProvidersListRespModel{Providers: hookCommon.List()})
+}
+
+// DeadLetterEntryRespModel is a single dead-lettered job as returned by
+// DeadLetterListHandler.
+type DeadLetterEntryRespModel struct {
+	ID        string    `json:"id"`
+	ServiceID string    `json:"service_id"`
+	AppSlug   string    `json:"app_slug"`
+	Attempt   int       `json:"attempt"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// DeadLetterListRespModel ...
+type DeadLetterListRespModel struct {
+	Entries []DeadLetterEntryRespModel `json:"entries"`
+}
+
+// DeadLetterListHandler handles GET /admin/dead-letter, listing every
+// build trigger job that exhausted its retry attempts.
+func DeadLetterListHandler(w http.ResponseWriter, r *http.Request) {
+	ensureAsyncStarted()
+
+	entries := deadLetterStore.List()
+	resp := DeadLetterListRespModel{Entries: make([]DeadLetterEntryRespModel, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, DeadLetterEntryRespModel{
+			ID:        e.ID,
+			ServiceID: e.Job.ServiceID,
+			AppSlug:   e.Job.AppSlug,
+			Attempt:   e.Job.Attempt,
+			LastError: e.LastErr,
+			FailedAt:  e.FailedAt,
+		})
+	}
+	service.RespondWithSuccess(w, resp)
+}
+
+// DeadLetterRetryHandler handles POST /admin/dead-letter/{id}/retry,
+// re-enqueueing a dead-lettered job for another attempt.
+func DeadLetterRetryHandler(w http.ResponseWriter, r *http.Request) {
+	ensureAsyncStarted()
+
+	id := mux.Vars(r)["id"]
+	job, ok := deadLetterStore.Retry(id)
+	if !ok {
+		respondWithSingleErrorStr(w, fmt.Sprintf("No dead-letter entry found for id: %s", id), hookCommon.CodeInvalidRequest, http.StatusNotFound)
+		return
+	}
+
+	job.Attempt = 0
+	job.NextAttemptAt = time.Time{}
+	if err := buildQueue.Enqueue(job); err != nil {
+		respondWithSingleError(w, err, hookCommon.CodeInvalidRequest, http.StatusInternalServerError)
+		return
+	}
+
+	service.RespondWithSuccess(w, SuccessRespModel{Message: fmt.Sprintf("Job %s re-enqueued for retry.", id)})
+}
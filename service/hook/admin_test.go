@@ -0,0 +1,107 @@
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+	"github.com/gorilla/mux"
+)
+
+func TestProvidersListHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	ProvidersListHandler(w, httptest.NewRequest(http.MethodGet, "/providers", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp ProvidersListRespModel
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+
+	found := false
+	for _, id := range resp.Providers {
+		if id == "github" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected %q to be in the providers list, got %v", "github", resp.Providers)
+	}
+}
+
+func TestDeadLetterListAndRetryHandlers(t *testing.T) {
+	ensureAsyncStarted()
+
+	job := queue.Job{ID: "dl-job-1", ServiceID: "github", AppSlug: "test-app", Attempt: 4}
+	deadLetterStore.Add(job, nil)
+
+	listW := httptest.NewRecorder()
+	DeadLetterListHandler(listW, httptest.NewRequest(http.MethodGet, "/admin/dead-letter", nil))
+
+	if listW.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, listW.Code)
+	}
+	var listResp DeadLetterListRespModel
+	if err := json.NewDecoder(listW.Body).Decode(&listResp); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	found := false
+	for _, e := range listResp.Entries {
+		if e.ID == job.ID {
+			found = true
+			if e.Attempt != 4 {
+				t.Errorf("expected Attempt %d, got %d", 4, e.Attempt)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected %q to be in the dead-letter list, got %+v", job.ID, listResp.Entries)
+	}
+
+	lenBefore, _ := buildQueue.Len()
+
+	retryReq := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/admin/dead-letter/"+job.ID+"/retry", nil), map[string]string{"id": job.ID})
+	retryW := httptest.NewRecorder()
+	DeadLetterRetryHandler(retryW, retryReq)
+
+	if retryW.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body %s", http.StatusOK, retryW.Code, retryW.Body.String())
+	}
+
+	lenAfter, _ := buildQueue.Len()
+	if lenAfter != lenBefore+1 {
+		t.Fatalf("expected the job to reappear in the queue, len went from %d to %d", lenBefore, lenAfter)
+	}
+
+	requeued, ok, err := buildQueue.Dequeue()
+	if err != nil || !ok {
+		t.Fatalf("expected to dequeue the re-enqueued job, ok=%v err=%v", ok, err)
+	}
+	if requeued.ID != job.ID {
+		t.Errorf("expected the re-enqueued job id %q, got %q", job.ID, requeued.ID)
+	}
+	if requeued.Attempt != 0 {
+		t.Errorf("expected Attempt to be reset to 0, got %d", requeued.Attempt)
+	}
+
+	if _, ok := deadLetterStore.Retry(job.ID); ok {
+		t.Error("expected the entry to be removed from the dead-letter store after retry")
+	}
+}
+
+func TestDeadLetterRetryHandler_UnknownID(t *testing.T) {
+	ensureAsyncStarted()
+
+	retryReq := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/admin/dead-letter/does-not-exist/retry", nil), map[string]string{"id": "does-not-exist"})
+	w := httptest.NewRecorder()
+	DeadLetterRetryHandler(w, retryReq)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
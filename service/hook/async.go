@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+	"github.com/bitrise-io/bitrise-webhooks/config"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/deadletter"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/worker"
+)
+
+var (
+	asyncOnce       sync.Once
+	buildQueue      queue.Queue
+	deadLetterStore deadletter.Store
+)
+
+// ensureAsyncStarted lazily creates the build trigger queue (backend
+// selected via config.QueueBackend), dead-letter store and worker pool,
+// and starts the pool draining the queue. It's a no-op after the first
+// call.
+func ensureAsyncStarted() {
+	asyncOnce.Do(func() {
+		buildQueue = newQueue()
+		deadLetterStore = newDeadLetterStore()
+
+		pool := worker.NewPool(buildQueue, deadLetterStore, triggerBuildJob, config.WorkerPoolSize(), config.MaxTriggerAttempts())
+		pool.Start(context.Background())
+	})
+}
+
+// newQueue builds the queue.Queue backend selected by config.QueueBackend,
+// falling back to the in-memory queue if "bolt" fails to open (e.g. a bad
+// path), so a misconfiguration doesn't take the whole service down.
+func newQueue() queue.Queue {
+	if config.QueueBackend() == "bolt" {
+		boltQueue, err := queue.NewBoltQueue(config.QueueBoltPath())
+		if err != nil {
+			log.Printf(" [!] Exception: hook: failed to open Bolt queue at %q, falling back to in-memory: %s", config.QueueBoltPath(), err)
+			return queue.NewInMemoryQueue()
+		}
+		return boltQueue
+	}
+	return queue.NewInMemoryQueue()
+}
+
+// newDeadLetterStore builds the deadletter.Store backend matching
+// config.QueueBackend, so a "bolt" deployment's dead-lettered jobs
+// survive a restart alongside the durable queue they came from; falls
+// back to the in-memory store if "bolt" fails to open.
+func newDeadLetterStore() deadletter.Store {
+	if config.QueueBackend() == "bolt" {
+		boltStore, err := deadletter.NewBoltStore(config.DeadLetterBoltPath())
+		if err != nil {
+			log.Printf(" [!] Exception: hook: failed to open Bolt dead-letter store at %q, falling back to in-memory: %s", config.DeadLetterBoltPath(), err)
+			return deadletter.NewInMemoryStore()
+		}
+		return boltStore
+	}
+	return deadletter.NewInMemoryStore()
+}
+
+// triggerBuildJob performs the build trigger for a queued job. It calls
+// bitriseapi.TriggerBuild directly and returns its error unchanged, so the
+// worker pool can tell retryable (*bitriseapi.Error with Retryable set)
+// from permanent failures.
+func triggerBuildJob(job queue.Job) error {
+	u, err := triggerURLFor(job.AppSlug)
+	if err != nil {
+		return err
+	}
+	isOnlyLog := !(config.SendRequestToURL != nil || config.GetServerEnvMode() == config.ServerEnvModeProd)
+	_, err = bitriseapi.TriggerBuild(u, job.APIToken, job.Params, isOnlyLog)
+	return err
+}
+
+func triggerURLFor(appSlug string) (*url.URL, error) {
+	if config.SendRequestToURL != nil {
+		return config.SendRequestToURL, nil
+	}
+	u, err := bitriseapi.BuildTriggerURL("https://www.bitrise.io", appSlug)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Build Trigger URL: %s", err)
+	}
+	return u, nil
+}
+
+// enqueueTriggerBuilds queues one Job per TriggerAPIParamsModel so the
+// worker pool can process them asynchronously, decoupling the webhook
+// response from how long (or how many retries) the Bitrise trigger
+// actually takes.
+func enqueueTriggerBuilds(serviceID, appSlug, apiToken string, params []bitriseapi.TriggerAPIParamsModel) {
+	ensureAsyncStarted()
+
+	for _, p := range params {
+		job := queue.Job{
+			ID:        newJobID(),
+			ServiceID: serviceID,
+			AppSlug:   appSlug,
+			APIToken:  apiToken,
+			Params:    p,
+		}
+		if err := buildQueue.Enqueue(job); err != nil {
+			log.Printf(" [!] Exception: hook: failed to enqueue build trigger job: %s", err)
+		}
+	}
+}
+
+// newJobID returns a short random identifier for a queued job.
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("job-%p", b)
+	}
+	return fmt.Sprintf("%x", b)
+}
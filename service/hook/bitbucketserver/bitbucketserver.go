@@ -0,0 +1,223 @@
+// Package bitbucketserver implements the hookCommon.Provider interface
+// for Bitbucket Server / Data Center (on-prem Stash) webhooks, which use
+// a different event model than Bitbucket Cloud: events are identified by
+// the X-Event-Key header rather than a payload field, and push
+// notifications describe ref changes instead of Cloud's push.changes
+// list.
+package bitbucketserver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+)
+
+const (
+	eventKeyHeader = "X-Event-Key"
+
+	eventKeyRefsChanged       = "repo:refs_changed"
+	eventKeyPullRequestOpen   = "pr:opened"
+	eventKeyPullRequestSync   = "pr:from_ref_updated"
+	eventKeyPullRequestMerged = "pr:merged"
+
+	refTypeBranch = "BRANCH"
+	refTypeTag    = "TAG"
+
+	// deliveryIDHeader carries Bitbucket Server's per-request identifier,
+	// used for idempotency. requestTimestampHeader is an RFC3339
+	// timestamp some Bitbucket Server setups add via a pre-webhook proxy
+	// for replay protection; it's optional.
+	deliveryIDHeader       = "X-Request-Id"
+	requestTimestampHeader = "X-Request-Timestamp"
+
+	signatureHeader = "X-Hub-Signature"
+)
+
+func init() {
+	hookCommon.RegisterProvider("bitbucket-server", func() hookCommon.Provider { return HookProvider{} })
+}
+
+// HookProvider implements hookCommon.Provider for Bitbucket Server.
+type HookProvider struct{}
+
+type refsChangedModel struct {
+	Changes []struct {
+		RefID    string `json:"refId"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+		Type     string `json:"type"`
+		Ref      struct {
+			Type string `json:"type"`
+		} `json:"ref"`
+	} `json:"changes"`
+}
+
+type pullRequestRefModel struct {
+	ID           int    `json:"id"`
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+	Repository   struct {
+		Links struct {
+			Clone []struct {
+				Href string `json:"href"`
+				Name string `json:"name"`
+			} `json:"clone"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+type pullRequestEventModel struct {
+	PullRequest struct {
+		FromRef pullRequestRefModel `json:"fromRef"`
+		ToRef   pullRequestRefModel `json:"toRef"`
+	} `json:"pullRequest"`
+}
+
+// VerifySignature implements hookCommon.Provider.VerifySignature, checking
+// the request's X-Hub-Signature header (hex-encoded HMAC-SHA256 of the raw
+// body, keyed with secret) in constant time, the same scheme used by
+// Bitbucket Server's webhook plugin.
+func (hp HookProvider) VerifySignature(r *http.Request, secret string) error {
+	sigHeader := r.Header.Get(signatureHeader)
+	if sigHeader == "" {
+		return fmt.Errorf("Missing %s header", signatureHeader)
+	}
+	sigHex := strings.TrimPrefix(sigHeader, "sha256=")
+	expectedSig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return fmt.Errorf("Malformed %s header", signatureHeader)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read request body: %s", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	actualSig := mac.Sum(nil)
+
+	if !hmac.Equal(actualSig, expectedSig) {
+		return fmt.Errorf("Signature mismatch")
+	}
+	return nil
+}
+
+// DeliveryID implements hookCommon.Provider.DeliveryID.
+func (hp HookProvider) DeliveryID(r *http.Request) string {
+	return r.Header.Get(deliveryIDHeader)
+}
+
+// RequestTimestamp implements hookCommon.TimestampedProvider, for setups
+// that add requestTimestampHeader in front of Bitbucket Server.
+func (hp HookProvider) RequestTimestamp(r *http.Request) (time.Time, bool) {
+	v := r.Header.Get(requestTimestampHeader)
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Transform implements hookCommon.Provider.Transform.
+func (hp HookProvider) Transform(r *http.Request) hookCommon.TransformResultModel {
+	eventKey := r.Header.Get(eventKeyHeader)
+	if eventKey == "" {
+		return hookCommon.TransformResultModel{
+			Error: hookCommon.NewHookError(hookCommon.CodeMissingEventHeader, fmt.Sprintf("Missing %s header", eventKeyHeader)),
+		}
+	}
+
+	switch eventKey {
+	case eventKeyRefsChanged:
+		return transformRefsChanged(r)
+	case eventKeyPullRequestOpen, eventKeyPullRequestSync, eventKeyPullRequestMerged:
+		return transformPullRequest(r)
+	default:
+		return hookCommon.TransformResultModel{
+			ShouldSkip: true,
+			Error:      hookCommon.NewHookError(hookCommon.CodeUnsupportedEvent, fmt.Sprintf("Unsupported Bitbucket Server event type: %s", eventKey)),
+		}
+	}
+}
+
+func transformRefsChanged(r *http.Request) hookCommon.TransformResultModel {
+	var event refsChangedModel
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return hookCommon.TransformResultModel{
+			Error: hookCommon.NewHookError(hookCommon.CodeMalformedPayload, fmt.Sprintf("Failed to parse request body as JSON: %s", err)),
+		}
+	}
+
+	triggerParams := []bitriseapi.TriggerAPIParamsModel{}
+	for _, change := range event.Changes {
+		switch change.Ref.Type {
+		case refTypeBranch:
+			branch := strings.TrimPrefix(change.RefID, "refs/heads/")
+			triggerParams = append(triggerParams, bitriseapi.TriggerAPIParamsModel{
+				BuildParams: bitriseapi.BuildParamsModel{
+					Branch:     branch,
+					CommitHash: change.ToHash,
+				},
+			})
+		case refTypeTag:
+			tag := strings.TrimPrefix(change.RefID, "refs/tags/")
+			triggerParams = append(triggerParams, bitriseapi.TriggerAPIParamsModel{
+				BuildParams: bitriseapi.BuildParamsModel{
+					Tag:        tag,
+					CommitHash: change.ToHash,
+				},
+			})
+		default:
+			continue
+		}
+	}
+
+	if len(triggerParams) < 1 {
+		return hookCommon.TransformResultModel{
+			ShouldSkip: true,
+			Error:      hookCommon.NewHookError(hookCommon.CodeNoBuildableEvent, "No changes with a buildable ref type (BRANCH/TAG) found"),
+		}
+	}
+
+	return hookCommon.TransformResultModel{TriggerAPIParams: triggerParams}
+}
+
+func transformPullRequest(r *http.Request) hookCommon.TransformResultModel {
+	var event pullRequestEventModel
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return hookCommon.TransformResultModel{
+			Error: hookCommon.NewHookError(hookCommon.CodeMalformedPayload, fmt.Sprintf("Failed to parse request body as JSON: %s", err)),
+		}
+	}
+
+	fromRef := event.PullRequest.FromRef
+	toRef := event.PullRequest.ToRef
+
+	return hookCommon.TransformResultModel{
+		TriggerAPIParams: []bitriseapi.TriggerAPIParamsModel{
+			{
+				BuildParams: bitriseapi.BuildParamsModel{
+					CommitHash:             fromRef.LatestCommit,
+					Branch:                 fromRef.DisplayID,
+					PullRequestHeadBranch:  fromRef.DisplayID,
+					PullRequestMergeBranch: toRef.DisplayID,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,227 @@
+package bitbucketserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+)
+
+func newPullRequestRequest(t *testing.T, eventKey, body string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set(eventKeyHeader, eventKey)
+	return r
+}
+
+func TestTransform_PullRequestOpened(t *testing.T) {
+	body := `{
+		"pullRequest": {
+			"fromRef": {"id": 1, "displayId": "feature/foo", "latestCommit": "abc123"},
+			"toRef": {"id": 2, "displayId": "main", "latestCommit": "def456"}
+		}
+	}`
+	r := newPullRequestRequest(t, eventKeyPullRequestOpen, body)
+
+	result := HookProvider{}.Transform(r)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if len(result.TriggerAPIParams) != 1 {
+		t.Fatalf("expected 1 trigger param, got %d", len(result.TriggerAPIParams))
+	}
+	bp := result.TriggerAPIParams[0].BuildParams
+	if bp.CommitHash != "abc123" {
+		t.Errorf("expected CommitHash %q, got %q", "abc123", bp.CommitHash)
+	}
+	if bp.Branch != "feature/foo" {
+		t.Errorf("expected Branch %q (source branch), got %q", "feature/foo", bp.Branch)
+	}
+	if bp.PullRequestMergeBranch != "main" {
+		t.Errorf("expected PullRequestMergeBranch %q, got %q", "main", bp.PullRequestMergeBranch)
+	}
+}
+
+func newRefsChangedRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set(eventKeyHeader, eventKeyRefsChanged)
+	return r
+}
+
+func TestTransform_RefsChanged(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantShouldSkip bool
+		wantParams     []struct {
+			branch string
+			tag    string
+			commit string
+		}
+	}{
+		{
+			name: "branch push",
+			body: `{"changes":[{"refId":"refs/heads/main","toHash":"abc123","ref":{"type":"BRANCH"}}]}`,
+			wantParams: []struct {
+				branch string
+				tag    string
+				commit string
+			}{
+				{branch: "main", commit: "abc123"},
+			},
+		},
+		{
+			name: "tag push",
+			body: `{"changes":[{"refId":"refs/tags/v1.0.0","toHash":"def456","ref":{"type":"TAG"}}]}`,
+			wantParams: []struct {
+				branch string
+				tag    string
+				commit string
+			}{
+				{tag: "v1.0.0", commit: "def456"},
+			},
+		},
+		{
+			name: "branch and tag push",
+			body: `{"changes":[
+				{"refId":"refs/heads/develop","toHash":"aaa111","ref":{"type":"BRANCH"}},
+				{"refId":"refs/tags/v2.0.0","toHash":"bbb222","ref":{"type":"TAG"}}
+			]}`,
+			wantParams: []struct {
+				branch string
+				tag    string
+				commit string
+			}{
+				{branch: "develop", commit: "aaa111"},
+				{tag: "v2.0.0", commit: "bbb222"},
+			},
+		},
+		{
+			name:           "unsupported ref type is skipped",
+			body:           `{"changes":[{"refId":"refs/heads/main","toHash":"abc123","ref":{"type":"SOMETHING_ELSE"}}]}`,
+			wantShouldSkip: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := HookProvider{}.Transform(newRefsChangedRequest(t, test.body))
+
+			if test.wantShouldSkip {
+				if !result.ShouldSkip {
+					t.Fatalf("expected ShouldSkip, got result: %+v", result)
+				}
+				return
+			}
+
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if len(result.TriggerAPIParams) != len(test.wantParams) {
+				t.Fatalf("expected %d trigger params, got %d", len(test.wantParams), len(result.TriggerAPIParams))
+			}
+			for i, want := range test.wantParams {
+				bp := result.TriggerAPIParams[i].BuildParams
+				if bp.Branch != want.branch {
+					t.Errorf("param %d: expected Branch %q, got %q", i, want.branch, bp.Branch)
+				}
+				if bp.Tag != want.tag {
+					t.Errorf("param %d: expected Tag %q, got %q", i, want.tag, bp.Tag)
+				}
+				if bp.CommitHash != want.commit {
+					t.Errorf("param %d: expected CommitHash %q, got %q", i, want.commit, bp.CommitHash)
+				}
+			}
+		})
+	}
+}
+
+func TestTransform_ErrorPathsReturnHookErrorsWithDistinctCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode string
+	}{
+		{
+			name:     "missing event key header",
+			r:        httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")),
+			wantCode: hookCommon.CodeMissingEventHeader,
+		},
+		{
+			name:     "unsupported event type",
+			r:        newPullRequestRequest(t, "pr:deleted", "{}"),
+			wantCode: hookCommon.CodeUnsupportedEvent,
+		},
+		{
+			name:     "malformed JSON body (refs_changed)",
+			r:        newRefsChangedRequest(t, "not-json"),
+			wantCode: hookCommon.CodeMalformedPayload,
+		},
+		{
+			name:     "malformed JSON body (pull request)",
+			r:        newPullRequestRequest(t, eventKeyPullRequestOpen, "not-json"),
+			wantCode: hookCommon.CodeMalformedPayload,
+		},
+		{
+			name:     "no buildable ref in refs_changed",
+			r:        newRefsChangedRequest(t, `{"changes":[{"refId":"refs/heads/main","toHash":"abc","ref":{"type":"SOMETHING_ELSE"}}]}`),
+			wantCode: hookCommon.CodeNoBuildableEvent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := HookProvider{}.Transform(test.r)
+
+			var hookErr *hookCommon.HookError
+			if !errors.As(result.Error, &hookErr) {
+				t.Fatalf("expected a *hookCommon.HookError, got %T: %v", result.Error, result.Error)
+			}
+			if hookErr.Code != test.wantCode {
+				t.Errorf("expected Code %q, got %q", test.wantCode, hookErr.Code)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := "s3cr3t"
+	body := `{"hello":"world"}`
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	validSig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	t.Run("valid signature", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set(signatureHeader, validSig)
+
+		if err := (HookProvider{}).VerifySignature(r, secret); err != nil {
+			t.Errorf("expected no error, got: %s", err)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set(signatureHeader, "sha256=deadbeef")
+
+		if err := (HookProvider{}).VerifySignature(r, secret); err == nil {
+			t.Error("expected an error for a mismatched signature")
+		}
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+		if err := (HookProvider{}).VerifySignature(r, secret); err == nil {
+			t.Error("expected an error for a missing signature header")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Package hookCommon defines the interface every webhook provider has to
+// implement, plus the shared result model providers hand back to
+// HTTPHandler.
+package hookCommon
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+)
+
+// TransformResultModel is what a Provider.Transform call returns.
+type TransformResultModel struct {
+	TriggerAPIParams []bitriseapi.TriggerAPIParamsModel
+	ShouldSkip       bool
+	Error            error
+}
+
+// Provider is the interface every webhook provider (GitHub, Bitbucket,
+// ...) has to implement to be usable from HTTPHandler.
+type Provider interface {
+	Transform(r *http.Request) TransformResultModel
+	// VerifySignature checks the request against secret using whatever
+	// signing scheme the provider's webhooks use (e.g. GitHub's
+	// X-Hub-Signature-256), and returns a non-nil error if it doesn't
+	// match. Providers that don't support signing yet can embed
+	// NoOpSignatureVerifier to satisfy this with an always-nil
+	// implementation.
+	VerifySignature(r *http.Request, secret string) error
+	// DeliveryID returns the provider's per-request delivery identifier
+	// (e.g. GitHub's X-GitHub-Delivery), used to deduplicate retried
+	// deliveries. An empty string means the request carries no
+	// identifier HTTPHandler can key an idempotency check on.
+	DeliveryID(r *http.Request) string
+}
+
+// TimestampedProvider is an optional interface a Provider can implement
+// when its webhooks carry a request timestamp HTTPHandler can use to
+// reject deliveries older than the configured replay-protection skew
+// window.
+type TimestampedProvider interface {
+	// RequestTimestamp returns the time the request claims to have been
+	// sent, if the provider's webhooks include one.
+	RequestTimestamp(r *http.Request) (time.Time, bool)
+}
+
+// NoOpSignatureVerifier is embeddable by Provider implementations that
+// don't (yet) support signature verification.
+type NoOpSignatureVerifier struct{}
+
+// VerifySignature always succeeds.
+func (NoOpSignatureVerifier) VerifySignature(r *http.Request, secret string) error {
+	return nil
+}
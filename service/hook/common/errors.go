@@ -0,0 +1,59 @@
+package hookCommon
+
+import "net/http"
+
+// Stable, machine-readable error codes returned as HookError.Code, so
+// webhook senders and dashboards can branch on them instead of parsing a
+// free-form message.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeUnsupportedProvider = "unsupported_provider"
+	CodeSignatureInvalid    = "signature_invalid"
+	CodeTransformFailed     = "transform_failed"
+	CodeTriggerFailed       = "trigger_failed"
+	CodeNoBuildableEvent    = "no_buildable_event"
+
+	// CodeMissingEventHeader, CodeUnsupportedEvent and
+	// CodeMalformedPayload are used by provider Transform implementations
+	// to report distinct reasons within the broader "transform failed" /
+	// "no buildable event" outcome, so callers can branch on e.g. a
+	// missing event header vs. a JSON decode failure instead of a single
+	// generic code.
+	CodeMissingEventHeader = "missing_event_header"
+	CodeUnsupportedEvent   = "unsupported_event"
+	CodeMalformedPayload   = "malformed_payload"
+)
+
+// HookError is the structured error type every error path in HTTPHandler
+// and each provider's Transform implementation should return.
+type HookError struct {
+	Code       string                 `json:"code"`
+	Message    string                 `json:"message"`
+	HTTPStatus int                    `json:"-"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *HookError) Error() string {
+	return e.Message
+}
+
+// NewHookError creates a HookError defaulting to HTTP 400 Bad Request;
+// use WithStatus to override it.
+func NewHookError(code, message string) *HookError {
+	return &HookError{Code: code, Message: message, HTTPStatus: http.StatusBadRequest}
+}
+
+// WithStatus returns a copy of e with HTTPStatus set to status.
+func (e *HookError) WithStatus(status int) *HookError {
+	dup := *e
+	dup.HTTPStatus = status
+	return &dup
+}
+
+// WithDetails returns a copy of e with Details set.
+func (e *HookError) WithDetails(details map[string]interface{}) *HookError {
+	dup := *e
+	dup.Details = details
+	return &dup
+}
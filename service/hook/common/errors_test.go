@@ -0,0 +1,47 @@
+package hookCommon
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewHookErrorDefaultsToBadRequest(t *testing.T) {
+	err := NewHookError(CodeInvalidRequest, "something went wrong")
+
+	if err.Code != CodeInvalidRequest {
+		t.Errorf("expected Code %q, got %q", CodeInvalidRequest, err.Code)
+	}
+	if err.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("expected default HTTPStatus %d, got %d", http.StatusBadRequest, err.HTTPStatus)
+	}
+	if err.Error() != "something went wrong" {
+		t.Errorf("expected Error() to return the message, got %q", err.Error())
+	}
+}
+
+func TestWithStatusReturnsACopy(t *testing.T) {
+	original := NewHookError(CodeTriggerFailed, "failed")
+
+	withStatus := original.WithStatus(http.StatusBadGateway)
+
+	if original.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("expected original HTTPStatus to stay %d, got %d", http.StatusBadRequest, original.HTTPStatus)
+	}
+	if withStatus.HTTPStatus != http.StatusBadGateway {
+		t.Errorf("expected HTTPStatus %d, got %d", http.StatusBadGateway, withStatus.HTTPStatus)
+	}
+}
+
+func TestWithDetailsReturnsACopy(t *testing.T) {
+	original := NewHookError(CodeTransformFailed, "failed")
+	details := map[string]interface{}{"field": "ref"}
+
+	withDetails := original.WithDetails(details)
+
+	if original.Details != nil {
+		t.Errorf("expected original Details to stay nil, got %v", original.Details)
+	}
+	if withDetails.Details["field"] != "ref" {
+		t.Errorf("expected Details[field] %q, got %v", "ref", withDetails.Details["field"])
+	}
+}
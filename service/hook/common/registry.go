@@ -0,0 +1,73 @@
+package hookCommon
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() Provider{}
+)
+
+// RegisterProvider registers a provider factory under id, so it becomes
+// available to HTTPHandler without the handler referencing the provider
+// package directly; a blank import of the package is enough. Intended to
+// be called from each provider package's init().
+func RegisterProvider(id string, factory func() Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[id] = factory
+}
+
+// Lookup returns a fresh Provider instance registered under id.
+func Lookup(id string) (Provider, bool) {
+	registryMu.RLock()
+	factory, ok := registry[id]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+// List returns the sorted ids of every registered provider.
+func List() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	ids := make([]string, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RouteRegistrar is implemented by providers that need to contribute
+// extra sub-routes beyond HTTPHandler's generic
+// /h/{service-id}/{app-slug}/{api-token} endpoint - install callbacks,
+// OAuth redirects, health pings, and the like.
+type RouteRegistrar interface {
+	RegisterRoutes(r *mux.Router)
+}
+
+// RegisterRoutes calls RegisterRoutes on every registered provider that
+// implements RouteRegistrar, mounting their provider-specific endpoints
+// under r.
+func RegisterRoutes(r *mux.Router) {
+	registryMu.RLock()
+	factories := make([]func() Provider, 0, len(registry))
+	for _, factory := range registry {
+		factories = append(factories, factory)
+	}
+	registryMu.RUnlock()
+
+	for _, factory := range factories {
+		if registrar, ok := factory().(RouteRegistrar); ok {
+			registrar.RegisterRoutes(r)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package hookCommon
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) Transform(r *http.Request) TransformResultModel       { return TransformResultModel{} }
+func (stubProvider) VerifySignature(r *http.Request, secret string) error { return nil }
+func (stubProvider) DeliveryID(r *http.Request) string                    { return "" }
+
+func TestRegisterProviderAndLookup(t *testing.T) {
+	RegisterProvider("stub-a", func() Provider { return stubProvider{} })
+
+	provider, ok := Lookup("stub-a")
+	if !ok {
+		t.Fatal("expected stub-a to be registered")
+	}
+	if _, ok := provider.(stubProvider); !ok {
+		t.Errorf("expected a stubProvider, got %T", provider)
+	}
+
+	if _, ok := Lookup("does-not-exist"); ok {
+		t.Error("expected Lookup of an unregistered id to return false")
+	}
+}
+
+func TestList(t *testing.T) {
+	RegisterProvider("stub-z", func() Provider { return stubProvider{} })
+	RegisterProvider("stub-b", func() Provider { return stubProvider{} })
+
+	ids := List()
+
+	var gotZ, gotB bool
+	for i, id := range ids {
+		if id == "stub-z" {
+			gotZ = true
+		}
+		if id == "stub-b" {
+			gotB = true
+			if i > 0 && ids[i-1] > id {
+				t.Errorf("expected List() to be sorted, got %v", ids)
+			}
+		}
+	}
+	if !gotZ || !gotB {
+		t.Errorf("expected List() to include registered ids, got %v", ids)
+	}
+	if !reflect.DeepEqual(ids, append([]string(nil), sortedCopy(ids)...)) {
+		t.Errorf("expected List() to return a sorted slice, got %v", ids)
+	}
+}
+
+func sortedCopy(ids []string) []string {
+	cp := append([]string(nil), ids...)
+	for i := 1; i < len(cp); i++ {
+		for j := i; j > 0 && cp[j-1] > cp[j]; j-- {
+			cp[j-1], cp[j] = cp[j], cp[j-1]
+		}
+	}
+	return cp
+}
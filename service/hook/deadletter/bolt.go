@@ -0,0 +1,100 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+	"go.etcd.io/bbolt"
+)
+
+var entriesBucket = []byte("entries")
+
+// BoltStore is a Store backed by a BoltDB file, so dead-lettered jobs
+// survive a process restart. Intended to be paired with queue.BoltQueue,
+// so an operator durable about the queue doesn't lose jobs that exhaust
+// their retries.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB-backed dead-letter
+// store at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open dead-letter database: %s", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(entriesBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create entries bucket: %s", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// Add implements Store.Add.
+func (s *BoltStore) Add(job queue.Job, lastErr error) {
+	errStr := ""
+	if lastErr != nil {
+		errStr = lastErr.Error()
+	}
+
+	entry := Entry{ID: job.ID, Job: job, LastErr: errStr, FailedAt: time.Now()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	_ = s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(entriesBucket).Put([]byte(entry.ID), data)
+	})
+}
+
+// List implements Store.List.
+func (s *BoltStore) List() []Entry {
+	var entries []Entry
+	_ = s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("Failed to unmarshal dead-letter entry %q: %s", k, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries
+}
+
+// Retry implements Store.Retry.
+func (s *BoltStore) Retry(id string) (queue.Job, bool) {
+	var entry Entry
+	var ok bool
+
+	_ = s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(entriesBucket)
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("Failed to unmarshal dead-letter entry %q: %s", id, err)
+		}
+		ok = true
+		return b.Delete([]byte(id))
+	})
+
+	if !ok {
+		return queue.Job{}, false
+	}
+	return entry.Job, true
+}
@@ -0,0 +1,87 @@
+// Package deadletter stores build trigger jobs that exhausted their
+// retry attempts, so operators can inspect and manually retry them
+// through the admin endpoints served by DeadLetterListHandler /
+// DeadLetterRetryHandler.
+package deadletter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+)
+
+// Entry is a job that gave up retrying, together with the error from its
+// last attempt.
+type Entry struct {
+	ID       string
+	Job      queue.Job
+	LastErr  string
+	FailedAt time.Time
+}
+
+// Store is a queryable collection of dead-lettered jobs.
+type Store interface {
+	// Add records job as dead, keyed by job.ID.
+	Add(job queue.Job, lastErr error)
+	// List returns every currently dead-lettered entry.
+	List() []Entry
+	// Retry removes id from the store and returns its Job so the caller
+	// can re-enqueue it. ok is false if id isn't known.
+	Retry(id string) (job queue.Job, ok bool)
+}
+
+// InMemoryStore is the default Store, backed by a mutex-guarded map. It's
+// process-local: dead-lettered jobs don't survive a restart, matching the
+// in-memory Queue's durability guarantees.
+type InMemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewInMemoryStore creates an empty InMemoryStore.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{entries: map[string]Entry{}}
+}
+
+// Add implements Store.Add.
+func (s *InMemoryStore) Add(job queue.Job, lastErr error) {
+	errStr := ""
+	if lastErr != nil {
+		errStr = lastErr.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[job.ID] = Entry{
+		ID:       job.ID,
+		Job:      job,
+		LastErr:  errStr,
+		FailedAt: time.Now(),
+	}
+}
+
+// List implements Store.List.
+func (s *InMemoryStore) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Retry implements Store.Retry.
+func (s *InMemoryStore) Retry(id string) (queue.Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return queue.Job{}, false
+	}
+	delete(s.entries, id)
+	return e.Job, true
+}
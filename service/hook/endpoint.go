@@ -5,44 +5,100 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
 	"github.com/bitrise-io/bitrise-webhooks/config"
 	"github.com/bitrise-io/bitrise-webhooks/metrics"
 	"github.com/bitrise-io/bitrise-webhooks/service"
 	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
-	"github.com/bitrise-io/bitrise-webhooks/service/hook/github"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/idempotency"
+
+	// Registers itself with hookCommon on import; see each package's init().
+	_ "github.com/bitrise-io/bitrise-webhooks/service/hook/bitbucketserver"
+	_ "github.com/bitrise-io/bitrise-webhooks/service/hook/github"
+
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
 )
 
-func supportedProviders() map[string]hookCommon.Provider {
-	return map[string]hookCommon.Provider{
-		"github": github.HookProvider{},
-		// "bitbucket-v2": bitbucketv2.HookProvider{},
-	}
+var (
+	idempotencyCacheOnce sync.Once
+	idempotencyCache     idempotency.Cache
+)
+
+// ensureIdempotencyCache lazily creates the idempotency cache (backend
+// selected via config.IdempotencyBackend) backing the delivery-ID replay
+// protection below.
+func ensureIdempotencyCache() idempotency.Cache {
+	idempotencyCacheOnce.Do(func() {
+		if config.IdempotencyBackend() == "redis" {
+			client := redis.NewClient(&redis.Options{Addr: config.IdempotencyRedisAddr()})
+			idempotencyCache = idempotency.NewRedisCache(client)
+			return
+		}
+		idempotencyCache = idempotency.NewLRUCache(config.IdempotencyLRUCapacity())
+	})
+	return idempotencyCache
+}
+
+// RegisterRoutes wires the core webhook endpoint, the provider
+// introspection endpoint and every registered provider's own routes (if
+// any) onto r.
+func RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/h/{service-id}/{app-slug}/{api-token}", HTTPHandler).Methods("POST")
+	r.HandleFunc("/providers", ProvidersListHandler).Methods("GET")
+	r.HandleFunc("/admin/dead-letter", DeadLetterListHandler).Methods("GET")
+	r.HandleFunc("/admin/dead-letter/{id}/retry", DeadLetterRetryHandler).Methods("POST")
+	hookCommon.RegisterRoutes(r)
 }
 
 // SuccessRespModel ...
 type SuccessRespModel struct {
 	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 // ErrorRespModel ...
 type ErrorRespModel struct {
-	Errors []error `json:"errors"`
+	Errors []*hookCommon.HookError `json:"errors"`
 }
 
-func respondWithSingleError(w http.ResponseWriter, err error) {
-	respondWithErrors(w, []error{err})
+// asHookError unwraps err into a *hookCommon.HookError, if it already is
+// (or wraps) one; otherwise it's reported under defaultCode/defaultStatus.
+func asHookError(err error, defaultCode string, defaultStatus int) *hookCommon.HookError {
+	var hookErr *hookCommon.HookError
+	if errors.As(err, &hookErr) {
+		return hookErr
+	}
+	return hookCommon.NewHookError(defaultCode, err.Error()).WithStatus(defaultStatus)
+}
+
+func respondWithSingleError(w http.ResponseWriter, err error, defaultCode string, defaultStatus int) {
+	respondWithErrors(w, []error{err}, defaultCode, defaultStatus)
 }
 
-func respondWithSingleErrorStr(w http.ResponseWriter, errStr string) {
-	respondWithSingleError(w, errors.New(errStr))
+func respondWithSingleErrorStr(w http.ResponseWriter, errStr string, defaultCode string, defaultStatus int) {
+	respondWithSingleError(w, errors.New(errStr), defaultCode, defaultStatus)
 }
 
-func respondWithErrors(w http.ResponseWriter, errs []error) {
-	service.RespondWithErrorJSON(w, http.StatusBadRequest, ErrorRespModel{Errors: errs})
+// respondWithErrors converts every err into a *hookCommon.HookError
+// (falling back to defaultCode/defaultStatus for plain errors) and
+// responds with the status code of the most severe one.
+func respondWithErrors(w http.ResponseWriter, errs []error, defaultCode string, defaultStatus int) {
+	hookErrors := make([]*hookCommon.HookError, 0, len(errs))
+	status := defaultStatus
+	for _, err := range errs {
+		hookErr := asHookError(err, defaultCode, defaultStatus)
+		hookErrors = append(hookErrors, hookErr)
+		if hookErr.HTTPStatus > status {
+			status = hookErr.HTTPStatus
+		}
+	}
+	service.RespondWithErrorJSON(w, status, ErrorRespModel{Errors: hookErrors})
 }
 
 func triggerBuild(triggerURL *url.URL, apiToken string, triggerAPIParams bitriseapi.TriggerAPIParamsModel) error {
@@ -50,7 +106,7 @@ func triggerBuild(triggerURL *url.URL, apiToken string, triggerAPIParams bitrise
 
 	_, err := bitriseapi.TriggerBuild(triggerURL, apiToken, triggerAPIParams, isOnlyLog)
 	if err != nil {
-		return fmt.Errorf("Failed to Trigger the Build: %s", err)
+		return hookCommon.NewHookError(hookCommon.CodeTriggerFailed, fmt.Sprintf("Failed to Trigger the Build: %s", err)).WithStatus(http.StatusBadGateway)
 	}
 	return nil
 }
@@ -63,23 +119,73 @@ func HTTPHandler(w http.ResponseWriter, r *http.Request) {
 	apiToken := vars["api-token"]
 
 	if serviceID == "" {
-		respondWithSingleErrorStr(w, "No service-id defined")
+		respondWithSingleErrorStr(w, "No service-id defined", hookCommon.CodeInvalidRequest, http.StatusBadRequest)
 		return
 	}
 	if appSlug == "" {
-		respondWithSingleErrorStr(w, "No App Slug parameter defined")
+		respondWithSingleErrorStr(w, "No App Slug parameter defined", hookCommon.CodeInvalidRequest, http.StatusBadRequest)
 		return
 	}
 	if apiToken == "" {
-		respondWithSingleErrorStr(w, "No API Token parameter defined")
+		respondWithSingleErrorStr(w, "No API Token parameter defined", hookCommon.CodeInvalidRequest, http.StatusBadRequest)
 		return
 	}
 
-	hookProvider, isSupported := supportedProviders()[serviceID]
+	hookProvider, isSupported := hookCommon.Lookup(serviceID)
 	if !isSupported {
-		respondWithSingleErrorStr(w, fmt.Sprintf("Unsupported Webhook Type / Provider: %s", serviceID))
+		respondWithSingleErrorStr(w, fmt.Sprintf("Unsupported Webhook Type / Provider: %s", serviceID), hookCommon.CodeUnsupportedProvider, http.StatusNotFound)
+		return
+	}
+
+	if tsProvider, ok := hookProvider.(hookCommon.TimestampedProvider); ok {
+		if ts, hasTimestamp := tsProvider.RequestTimestamp(r); hasTimestamp && time.Since(ts) > config.ReplaySkewWindow() {
+			respondWithSingleErrorStr(w, "Request timestamp is outside the allowed replay window", hookCommon.CodeSignatureInvalid, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	secret, hasSecret := config.SecretStore.Lookup(serviceID, appSlug)
+	if !hasSecret && config.RequireSignedWebhooks() {
+		respondWithSingleErrorStr(w, fmt.Sprintf("No webhook secret registered for service-id:%s app-slug:%s, and signed webhooks are required", serviceID, appSlug), hookCommon.CodeSignatureInvalid, http.StatusUnauthorized)
 		return
 	}
+	if hasSecret {
+		if err := hookProvider.VerifySignature(r, secret); err != nil {
+			respondWithSingleErrorStr(w, fmt.Sprintf("Webhook signature verification failed: %s", err), hookCommon.CodeSignatureInvalid, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// Only wrap the response for idempotency caching after signature
+	// verification succeeds, so an unauthenticated request can never
+	// poison the cache for a delivery-id a legitimate sender later
+	// retries.
+	if deliveryID := hookProvider.DeliveryID(r); deliveryID != "" {
+		idempotencyKey := serviceID + "/" + appSlug + "/" + deliveryID
+		if cached, ok := ensureIdempotencyCache().Get(idempotencyKey); ok {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(cached.StatusCode)
+			_, _ = w.Write(cached.Body)
+			return
+		}
+
+		realW := w
+		rec := httptest.NewRecorder()
+		w = rec
+		defer func() {
+			// Only cache a successful/queued outcome: GitHub and
+			// Bitbucket Server retry a delivery under the same
+			// delivery-id specifically when the first attempt failed,
+			// so caching an error response would permanently break
+			// that retry for the whole TTL window.
+			if rec.Code < http.StatusBadRequest {
+				ensureIdempotencyCache().Put(idempotencyKey, idempotency.Response{StatusCode: rec.Code, Body: rec.Body.Bytes()}, config.IdempotencyTTL())
+			}
+			realW.Header().Set("Content-Type", "application/json; charset=utf-8")
+			realW.WriteHeader(rec.Code)
+			_, _ = realW.Write(rec.Body.Bytes())
+		}()
+	}
 
 	hookTransformResult := hookCommon.TransformResultModel{}
 	metrics.Trace("Hook: Transform", func() {
@@ -87,16 +193,30 @@ func HTTPHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if hookTransformResult.ShouldSkip {
-		resp := SuccessRespModel{
-			Message: fmt.Sprintf("Acknowledged, but skipping. Reason: %s", hookTransformResult.Error),
+		reason := ""
+		if hookTransformResult.Error != nil {
+			reason = asHookError(hookTransformResult.Error, hookCommon.CodeNoBuildableEvent, http.StatusOK).Code
 		}
-		service.RespondWithSuccess(w, resp)
+		service.RespondWithSuccess(w, SuccessRespModel{
+			Message: fmt.Sprintf("Acknowledged, but skipping. Reason: %s", hookTransformResult.Error),
+			Reason:  reason,
+		})
 		return
 	}
 	if hookTransformResult.Error != nil {
-		errMsg := fmt.Sprintf("Failed to transform the webhook: %s", hookTransformResult.Error)
-		log.Printf(" (debug) %s", errMsg)
-		respondWithSingleErrorStr(w, errMsg)
+		log.Printf(" (debug) Failed to transform the webhook: %s", hookTransformResult.Error)
+		respondWithSingleError(w, hookTransformResult.Error, hookCommon.CodeTransformFailed, http.StatusBadRequest)
+		return
+	}
+
+	if len(hookTransformResult.TriggerAPIParams) == 0 {
+		respondWithSingleErrorStr(w, "After processing the webhook we failed to detect any event in it which could be turned into a build.", hookCommon.CodeNoBuildableEvent, http.StatusBadRequest)
+		return
+	}
+
+	if config.AsyncTriggerEnabled() {
+		enqueueTriggerBuilds(serviceID, appSlug, apiToken, hookTransformResult.TriggerAPIParams)
+		service.RespondWithSuccess(w, SuccessRespModel{Message: "Acknowledged, build trigger(s) queued."})
 		return
 	}
 
@@ -106,35 +226,32 @@ func HTTPHandler(w http.ResponseWriter, r *http.Request) {
 		u, err := bitriseapi.BuildTriggerURL("https://www.bitrise.io", appSlug)
 		if err != nil {
 			log.Printf(" [!] Exception: hookHandler: failed to create Build Trigger URL: %s", err)
-			respondWithSingleErrorStr(w, fmt.Sprintf("Failed to create Build Trigger URL: %s", err))
+			respondWithSingleErrorStr(w, fmt.Sprintf("Failed to create Build Trigger URL: %s", err), hookCommon.CodeInvalidRequest, http.StatusBadRequest)
 			return
 		}
 		triggerURL = u
 	}
 
-	respondWithErrors := []error{}
+	buildErrors := []error{}
 	buildTriggerCount := len(hookTransformResult.TriggerAPIParams)
 	metrics.Trace("Hook: Trigger Builds", func() {
-		if buildTriggerCount == 0 {
-			respondWithErrors = append(respondWithErrors, errors.New("After processing the webhook we failed to detect any event in it which could be turned into a build."))
-			return
-		} else if buildTriggerCount == 1 {
+		if buildTriggerCount == 1 {
 			err := triggerBuild(triggerURL, apiToken, hookTransformResult.TriggerAPIParams[0])
 			if err != nil {
-				respondWithErrors = append(respondWithErrors, err)
+				buildErrors = append(buildErrors, err)
 				return
 			}
 		} else {
 			for _, aBuildTriggerParam := range hookTransformResult.TriggerAPIParams {
 				if err := triggerBuild(triggerURL, apiToken, aBuildTriggerParam); err != nil {
-					respondWithErrors = append(respondWithErrors, err)
+					buildErrors = append(buildErrors, err)
 				}
 			}
 		}
 	})
 
-	if len(respondWithErrors) > 0 {
-		service.RespondWithErrorJSON(w, http.StatusBadRequest, ErrorRespModel{Errors: respondWithErrors})
+	if len(buildErrors) > 0 {
+		respondWithErrors(w, buildErrors, hookCommon.CodeTriggerFailed, http.StatusBadGateway)
 		return
 	}
 
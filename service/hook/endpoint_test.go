@@ -0,0 +1,65 @@
+package hook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+	"github.com/gorilla/mux"
+)
+
+func TestRespondWithErrorsUsesMostSevereStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	errs := []error{
+		hookCommon.NewHookError(hookCommon.CodeTransformFailed, "bad input").WithStatus(http.StatusBadRequest),
+		hookCommon.NewHookError(hookCommon.CodeTriggerFailed, "upstream down").WithStatus(http.StatusBadGateway),
+	}
+	respondWithErrors(w, errs, hookCommon.CodeInvalidRequest, http.StatusBadRequest)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected the most severe status %d, got %d", http.StatusBadGateway, w.Code)
+	}
+}
+
+func TestRespondWithErrorsWrapsPlainErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithErrors(w, []error{errors.New("boom")}, hookCommon.CodeInvalidRequest, http.StatusTeapot)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected default status %d for a plain error, got %d", http.StatusTeapot, w.Code)
+	}
+}
+
+func newGithubPushRequest(t *testing.T, deliveryID, body string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/h/github/test-app/test-token", strings.NewReader(body))
+	r.Header.Set("X-Github-Event", "push")
+	r.Header.Set("X-Github-Delivery", deliveryID)
+	r = mux.SetURLVars(r, map[string]string{
+		"service-id": "github",
+		"app-slug":   "test-app",
+		"api-token":  "test-token",
+	})
+	return r
+}
+
+func TestHTTPHandler_DoesNotReplayAFailedDeliveryFromCache(t *testing.T) {
+	deliveryID := "delivery-failure-not-cached"
+
+	w1 := httptest.NewRecorder()
+	HTTPHandler(w1, newGithubPushRequest(t, deliveryID, "not-json"))
+	if w1.Code != http.StatusBadRequest {
+		t.Fatalf("expected the first (malformed) request to fail with %d, got %d", http.StatusBadRequest, w1.Code)
+	}
+
+	w2 := httptest.NewRecorder()
+	HTTPHandler(w2, newGithubPushRequest(t, deliveryID, `{"ref":"refs/heads/main","head_commit":{"id":"abc123"}}`))
+	if w2.Code != http.StatusOK {
+		t.Fatalf("expected the retried delivery to be processed fresh, not replayed from a cached failure, got status %d body %s", w2.Code, w2.Body.String())
+	}
+}
@@ -0,0 +1,121 @@
+// Package github implements the hookCommon.Provider interface for
+// GitHub webhooks.
+package github
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+)
+
+const signatureHeader = "X-Hub-Signature-256"
+
+func init() {
+	hookCommon.RegisterProvider("github", func() hookCommon.Provider { return HookProvider{} })
+}
+
+const (
+	pushEventID        = "push"
+	pullRequestEventID = "pull_request"
+)
+
+// HookProvider implements hookCommon.Provider for GitHub.
+type HookProvider struct{}
+
+type pushEventModel struct {
+	Ref        string `json:"ref"`
+	HeadCommit struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"head_commit"`
+}
+
+// VerifySignature implements hookCommon.Provider.VerifySignature, checking
+// the request's X-Hub-Signature-256 header (hex-encoded HMAC-SHA256 of
+// the raw body, keyed with secret) in constant time.
+func (hp HookProvider) VerifySignature(r *http.Request, secret string) error {
+	sigHeader := r.Header.Get(signatureHeader)
+	if sigHeader == "" {
+		return fmt.Errorf("Missing %s header", signatureHeader)
+	}
+	sigHex := strings.TrimPrefix(sigHeader, "sha256=")
+	expectedSig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return fmt.Errorf("Malformed %s header", signatureHeader)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read request body: %s", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	actualSig := mac.Sum(nil)
+
+	if !hmac.Equal(actualSig, expectedSig) {
+		return fmt.Errorf("Signature mismatch")
+	}
+	return nil
+}
+
+// DeliveryID implements hookCommon.Provider.DeliveryID.
+func (hp HookProvider) DeliveryID(r *http.Request) string {
+	return r.Header.Get("X-Github-Delivery")
+}
+
+// Transform implements hookCommon.Provider.Transform.
+func (hp HookProvider) Transform(r *http.Request) hookCommon.TransformResultModel {
+	eventID := r.Header.Get("X-Github-Event")
+	if eventID == "" {
+		return hookCommon.TransformResultModel{
+			Error: hookCommon.NewHookError(hookCommon.CodeMissingEventHeader, "Missing X-Github-Event header"),
+		}
+	}
+
+	if eventID != pushEventID {
+		return hookCommon.TransformResultModel{
+			ShouldSkip: true,
+			Error:      hookCommon.NewHookError(hookCommon.CodeUnsupportedEvent, fmt.Sprintf("Unsupported GitHub event type: %s", eventID)),
+		}
+	}
+
+	var event pushEventModel
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		return hookCommon.TransformResultModel{
+			Error: hookCommon.NewHookError(hookCommon.CodeMalformedPayload, fmt.Sprintf("Failed to parse request body as JSON: %s", err)),
+		}
+	}
+
+	buildParams := bitriseapi.BuildParamsModel{
+		CommitHash:    event.HeadCommit.ID,
+		CommitMessage: event.HeadCommit.Message,
+	}
+	switch {
+	case strings.HasPrefix(event.Ref, "refs/heads/"):
+		buildParams.Branch = strings.TrimPrefix(event.Ref, "refs/heads/")
+	case strings.HasPrefix(event.Ref, "refs/tags/"):
+		buildParams.Tag = strings.TrimPrefix(event.Ref, "refs/tags/")
+	default:
+		return hookCommon.TransformResultModel{
+			ShouldSkip: true,
+			Error:      hookCommon.NewHookError(hookCommon.CodeNoBuildableEvent, fmt.Sprintf("Ref is neither a branch nor a tag: %s", event.Ref)),
+		}
+	}
+
+	return hookCommon.TransformResultModel{
+		TriggerAPIParams: []bitriseapi.TriggerAPIParamsModel{
+			{BuildParams: buildParams},
+		},
+	}
+}
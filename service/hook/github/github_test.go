@@ -0,0 +1,103 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hookCommon "github.com/bitrise-io/bitrise-webhooks/service/hook/common"
+)
+
+func newPushRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("X-Github-Event", pushEventID)
+	return r
+}
+
+func TestTransform_PushBranch(t *testing.T) {
+	r := newPushRequest(t, `{"ref":"refs/heads/main","head_commit":{"id":"abc123","message":"msg"}}`)
+
+	result := HookProvider{}.Transform(r)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if len(result.TriggerAPIParams) != 1 {
+		t.Fatalf("expected 1 trigger param, got %d", len(result.TriggerAPIParams))
+	}
+	bp := result.TriggerAPIParams[0].BuildParams
+	if bp.Branch != "main" {
+		t.Errorf("expected Branch %q, got %q", "main", bp.Branch)
+	}
+	if bp.Tag != "" {
+		t.Errorf("expected no Tag, got %q", bp.Tag)
+	}
+	if bp.CommitHash != "abc123" {
+		t.Errorf("expected CommitHash %q, got %q", "abc123", bp.CommitHash)
+	}
+}
+
+func TestTransform_PushTag(t *testing.T) {
+	r := newPushRequest(t, `{"ref":"refs/tags/v1.0.0","head_commit":{"id":"def456","message":"msg"}}`)
+
+	result := HookProvider{}.Transform(r)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	bp := result.TriggerAPIParams[0].BuildParams
+	if bp.Tag != "v1.0.0" {
+		t.Errorf("expected Tag %q, got %q", "v1.0.0", bp.Tag)
+	}
+	if bp.Branch != "" {
+		t.Errorf("expected no Branch, got %q", bp.Branch)
+	}
+}
+
+func TestTransform_ErrorPathsReturnHookErrorsWithDistinctCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode string
+	}{
+		{
+			name:     "missing event header",
+			r:        httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")),
+			wantCode: hookCommon.CodeMissingEventHeader,
+		},
+		{
+			name: "unsupported event type",
+			r: func() *http.Request {
+				r := newPushRequest(t, "{}")
+				r.Header.Set("X-Github-Event", "pull_request")
+				return r
+			}(),
+			wantCode: hookCommon.CodeUnsupportedEvent,
+		},
+		{
+			name:     "malformed JSON body",
+			r:        newPushRequest(t, "not-json"),
+			wantCode: hookCommon.CodeMalformedPayload,
+		},
+		{
+			name:     "ref is neither a branch nor a tag",
+			r:        newPushRequest(t, `{"ref":"refs/weird/x"}`),
+			wantCode: hookCommon.CodeNoBuildableEvent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := HookProvider{}.Transform(test.r)
+
+			var hookErr *hookCommon.HookError
+			if !errors.As(result.Error, &hookErr) {
+				t.Fatalf("expected a *hookCommon.HookError, got %T: %v", result.Error, result.Error)
+			}
+			if hookErr.Code != test.wantCode {
+				t.Errorf("expected Code %q, got %q", test.wantCode, hookErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package idempotency provides a TTL cache keyed by
+// (service-id, app-slug, delivery-id) so HTTPHandler can replay the
+// response to a retried webhook delivery instead of re-triggering the
+// build it already processed.
+package idempotency
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Response is the cached result of handling a webhook delivery.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Cache stores Responses keyed by an opaque string, each expiring after
+// its own TTL.
+type Cache interface {
+	// Get returns the cached Response for key, if present and not yet
+	// expired.
+	Get(key string) (Response, bool)
+	// Put stores resp under key for ttl.
+	Put(key string, resp Response, ttl time.Duration)
+}
+
+type entry struct {
+	key       string
+	resp      Response
+	expiresAt time.Time
+}
+
+// LRUCache is the default Cache: an in-memory, capacity-bounded LRU with
+// per-entry TTL. It's process-local, which is fine for single-instance
+// deployments; multi-instance deployments should use RedisCache instead.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// NewLRUCache creates an LRUCache holding at most capacity entries.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Get implements Cache.Get.
+func (c *LRUCache) Get(key string) (Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Response{}, false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.removeElement(el)
+		return Response{}, false
+	}
+	c.ll.MoveToFront(el)
+	return e.resp, true
+}
+
+// Put implements Cache.Put.
+func (c *LRUCache) Put(key string, resp Response, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry).resp = resp
+		el.Value.(*entry).expiresAt = time.Now().Add(ttl)
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&entry{key: key, resp: resp, expiresAt: time.Now().Add(ttl)})
+	c.items[key] = el
+
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.removeElement(oldest)
+		}
+	}
+}
+
+func (c *LRUCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
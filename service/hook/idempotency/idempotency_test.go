@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCache_GetAfterPut(t *testing.T) {
+	c := NewLRUCache(10)
+	c.Put("key", Response{StatusCode: 200, Body: []byte("ok")}, time.Minute)
+
+	resp, ok := c.Get("key")
+	if !ok {
+		t.Fatal("expected a cached response")
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "ok" {
+		t.Errorf("unexpected cached response: %+v", resp)
+	}
+}
+
+func TestLRUCache_GetMissing(t *testing.T) {
+	c := NewLRUCache(10)
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("expected no cached response for an unknown key")
+	}
+}
+
+func TestLRUCache_ExpiresAfterTTL(t *testing.T) {
+	c := NewLRUCache(10)
+	c.Put("key", Response{StatusCode: 200}, -time.Second)
+
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected an already-expired entry to be evicted on Get")
+	}
+}
+
+func TestLRUCache_EvictsOldestBeyondCapacity(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Put("a", Response{StatusCode: 200}, time.Minute)
+	c.Put("b", Response{StatusCode: 200}, time.Minute)
+	c.Put("c", Response{StatusCode: 200}, time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected the oldest entry to be evicted once capacity is exceeded")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("expected \"b\" to still be cached")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("expected \"c\" to still be cached")
+	}
+}
@@ -0,0 +1,48 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const keyPrefix = "bitrise-webhooks:idempotency:"
+
+// RedisCache is a Cache backed by Redis, for multi-instance deployments
+// where a process-local LRUCache wouldn't see deliveries handled by a
+// sibling instance.
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache creates a RedisCache using client.
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+// Get implements Cache.Get.
+func (c *RedisCache) Get(key string) (Response, bool) {
+	data, err := c.client.Get(context.Background(), keyPrefix+key).Bytes()
+	if err != nil {
+		return Response{}, false
+	}
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Response{}, false
+	}
+	return resp, true
+}
+
+// Put implements Cache.Put.
+func (c *RedisCache) Put(key string, resp Response, ttl time.Duration) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := c.client.Set(context.Background(), keyPrefix+key, data, ttl).Err(); err != nil {
+		log.Printf(" [!] Exception: idempotency: failed to store cached response in Redis: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var jobsBucket = []byte("jobs")
+
+// BoltQueue is a Queue backed by a BoltDB file, so queued jobs survive a
+// process restart. Intended for single-instance production deployments
+// that don't already run Redis.
+type BoltQueue struct {
+	db *bbolt.DB
+}
+
+// NewBoltQueue opens (creating if necessary) a BoltDB-backed queue at path.
+func NewBoltQueue(path string) (*BoltQueue, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open queue database: %s", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create jobs bucket: %s", err)
+	}
+	return &BoltQueue{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (q *BoltQueue) Close() error {
+	return q.db.Close()
+}
+
+// Enqueue implements Queue.Enqueue.
+func (q *BoltQueue) Enqueue(job Job) error {
+	return q.put(job)
+}
+
+// Dequeue implements Queue.Dequeue.
+func (q *BoltQueue) Dequeue() (Job, bool, error) {
+	var found Job
+	var ok bool
+	now := time.Now()
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("Failed to unmarshal queued job %q: %s", k, err)
+			}
+			if job.NextAttemptAt.After(now) {
+				continue
+			}
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			found, ok = job, true
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return Job{}, false, err
+	}
+	return found, ok, nil
+}
+
+// Release implements Queue.Release.
+func (q *BoltQueue) Release(job Job, nextAttemptAt time.Time) error {
+	job.Attempt++
+	job.NextAttemptAt = nextAttemptAt
+	return q.put(job)
+}
+
+// Len implements Queue.Len.
+func (q *BoltQueue) Len() (int, error) {
+	var n int
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		n = tx.Bucket(jobsBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func (q *BoltQueue) put(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal job: %s", err)
+	}
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := []byte(fmt.Sprintf("%020d-%s", seq, job.ID))
+		return b.Put(key, data)
+	})
+}
@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// InMemoryQueue is a process-local Queue backed by a slice guarded by a
+// mutex. It's the default backend, used in tests and for single-instance
+// deployments that don't need the jobs to survive a restart.
+type InMemoryQueue struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// NewInMemoryQueue creates an empty InMemoryQueue.
+func NewInMemoryQueue() *InMemoryQueue {
+	return &InMemoryQueue{}
+}
+
+// Enqueue implements Queue.Enqueue.
+func (q *InMemoryQueue) Enqueue(job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+// Dequeue implements Queue.Dequeue.
+func (q *InMemoryQueue) Dequeue() (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for i, job := range q.jobs {
+		if job.NextAttemptAt.After(now) {
+			continue
+		}
+		q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+		return job, true, nil
+	}
+	return Job{}, false, nil
+}
+
+// Release implements Queue.Release.
+func (q *InMemoryQueue) Release(job Job, nextAttemptAt time.Time) error {
+	job.Attempt++
+	job.NextAttemptAt = nextAttemptAt
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+// Len implements Queue.Len.
+func (q *InMemoryQueue) Len() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs), nil
+}
@@ -0,0 +1,40 @@
+// Package queue provides the persistent job queue backing the
+// asynchronous build-trigger worker pool: a Job is enqueued for every
+// TriggerAPIParamsModel produced by a provider's Transform call, and is
+// drained by the worker pool with retry / backoff until it succeeds or is
+// moved to the dead-letter queue.
+package queue
+
+import (
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+)
+
+// Job is a single queued build trigger.
+type Job struct {
+	ID            string
+	ServiceID     string
+	AppSlug       string
+	APIToken      string
+	Params        bitriseapi.TriggerAPIParamsModel
+	Attempt       int
+	NextAttemptAt time.Time
+}
+
+// Queue is a FIFO of Jobs; whether queued jobs survive a restart depends on
+// the implementation (BoltQueue does, InMemoryQueue does not).
+// Implementations must be safe for concurrent use by multiple worker
+// goroutines.
+type Queue interface {
+	// Enqueue adds a new job, ready to be picked up immediately.
+	Enqueue(job Job) error
+	// Dequeue returns the next job whose NextAttemptAt has elapsed, if
+	// any. ok is false when the queue currently has nothing ready.
+	Dequeue() (job Job, ok bool, err error)
+	// Release requeues job for a later attempt, after incrementing
+	// Attempt and setting NextAttemptAt.
+	Release(job Job, nextAttemptAt time.Time) error
+	// Len reports the number of jobs currently waiting in the queue.
+	Len() (int, error)
+}
@@ -0,0 +1,139 @@
+// Package worker implements the pool of goroutines that drain the build
+// trigger queue used by the asynchronous webhook handling path.
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/deadletter"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+)
+
+const (
+	backoffBase = 2 * time.Second
+	backoffCap  = 5 * time.Minute
+)
+
+// TriggerFunc performs the actual build trigger call for a job. It's a
+// function rather than a hard dependency on bitriseapi so tests can stub
+// it out.
+type TriggerFunc func(job queue.Job) error
+
+// Pool drains Queue with a fixed number of worker goroutines, retrying
+// failed jobs with exponential backoff and jitter until maxAttempts is
+// reached, at which point the job is moved to DeadLetter.
+type Pool struct {
+	Queue       queue.Queue
+	DeadLetter  deadletter.Store
+	Trigger     TriggerFunc
+	Size        int
+	MaxAttempts int
+
+	// pollInterval is how often idle workers re-check an empty queue.
+	pollInterval time.Duration
+}
+
+// NewPool creates a Pool ready to Start.
+func NewPool(q queue.Queue, dl deadletter.Store, trigger TriggerFunc, size int, maxAttempts int) *Pool {
+	return &Pool{
+		Queue:        q,
+		DeadLetter:   dl,
+		Trigger:      trigger,
+		Size:         size,
+		MaxAttempts:  maxAttempts,
+		pollInterval: 500 * time.Millisecond,
+	}
+}
+
+// Start launches Size worker goroutines that run until ctx is canceled.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.Size; i++ {
+		go p.runWorker(ctx)
+	}
+}
+
+func (p *Pool) runWorker(ctx context.Context) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.drainOnce(ctx)
+		}
+	}
+}
+
+func (p *Pool) drainOnce(ctx context.Context) {
+	for {
+		job, ok, err := p.Queue.Dequeue()
+		if err != nil {
+			log.Printf(" [!] Exception: worker: failed to dequeue job: %s", err)
+			return
+		}
+		if !ok {
+			return
+		}
+		p.process(job)
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+func (p *Pool) process(job queue.Job) {
+	err := p.Trigger(job)
+	if err == nil {
+		return
+	}
+
+	if !isRetryable(err) {
+		log.Printf(" (i) worker: job %s failed permanently, moving to dead-letter: %s", job.ID, err)
+		p.DeadLetter.Add(job, err)
+		return
+	}
+
+	if job.Attempt+1 >= p.MaxAttempts {
+		log.Printf(" (i) worker: job %s exhausted %d attempts, moving to dead-letter: %s", job.ID, p.MaxAttempts, err)
+		p.DeadLetter.Add(job, err)
+		return
+	}
+
+	delay := backoffWithJitter(job.Attempt)
+	log.Printf(" (i) worker: job %s attempt %d failed, retrying in %s: %s", job.ID, job.Attempt+1, delay, err)
+	if releaseErr := p.Queue.Release(job, time.Now().Add(delay)); releaseErr != nil {
+		log.Printf(" [!] Exception: worker: failed to requeue job %s: %s", job.ID, releaseErr)
+	}
+}
+
+// backoffWithJitter returns min(cap, base*2^attempt) + rand(0, base).
+func backoffWithJitter(attempt int) time.Duration {
+	d := backoffBase * time.Duration(1<<uint(attempt))
+	if d > backoffCap || d <= 0 {
+		d = backoffCap
+	}
+	return d + time.Duration(rand.Int63n(int64(backoffBase)))
+}
+
+// isRetryable distinguishes transient failures (5xx, network errors) from
+// permanent ones (4xx from bitriseapi.TriggerBuild), which should go
+// straight to the dead-letter queue without wasting retry attempts.
+func isRetryable(err error) bool {
+	var apiErr *bitriseapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable
+	}
+	// Unrecognized errors (e.g. failures before we even reach the
+	// Bitrise API) are assumed transient.
+	return true
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/bitrise-webhooks/bitriseapi"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/deadletter"
+	"github.com/bitrise-io/bitrise-webhooks/service/hook/queue"
+)
+
+func newTestPool(trigger TriggerFunc, maxAttempts int) (*Pool, *queue.InMemoryQueue, *deadletter.InMemoryStore) {
+	q := queue.NewInMemoryQueue()
+	dl := deadletter.NewInMemoryStore()
+	p := NewPool(q, dl, trigger, 1, maxAttempts)
+	return p, q, dl
+}
+
+func TestProcess_PermanentFailureGoesStraightToDeadLetter(t *testing.T) {
+	permanentErr := &bitriseapi.Error{StatusCode: 422, Retryable: false}
+	p, q, dl := newTestPool(func(job queue.Job) error { return permanentErr }, 5)
+
+	p.process(queue.Job{ID: "job-1"})
+
+	if n, _ := q.Len(); n != 0 {
+		t.Errorf("expected the job not to be requeued, queue len = %d", n)
+	}
+	if len(dl.List()) != 1 {
+		t.Fatalf("expected 1 dead-lettered job, got %d", len(dl.List()))
+	}
+}
+
+func TestProcess_TransientFailureIsRetried(t *testing.T) {
+	transientErr := errors.New("connection reset")
+	p, q, dl := newTestPool(func(job queue.Job) error { return transientErr }, 5)
+
+	p.process(queue.Job{ID: "job-1", Attempt: 0})
+
+	if len(dl.List()) != 0 {
+		t.Fatalf("expected no dead-lettered job yet, got %d", len(dl.List()))
+	}
+	if n, _ := q.Len(); n != 1 {
+		t.Fatalf("expected the job to be requeued, queue len = %d", n)
+	}
+}
+
+func TestProcess_ExhaustedAttemptsGoesToDeadLetter(t *testing.T) {
+	transientErr := errors.New("connection reset")
+	p, q, dl := newTestPool(func(job queue.Job) error { return transientErr }, 3)
+
+	p.process(queue.Job{ID: "job-1", Attempt: 2})
+
+	if n, _ := q.Len(); n != 0 {
+		t.Errorf("expected the job not to be requeued once attempts are exhausted, queue len = %d", n)
+	}
+	if len(dl.List()) != 1 {
+		t.Fatalf("expected 1 dead-lettered job, got %d", len(dl.List()))
+	}
+}
+
+func TestBackoffWithJitter_GrowsAndCaps(t *testing.T) {
+	if d := backoffWithJitter(0); d < backoffBase || d >= backoffBase*2 {
+		t.Errorf("expected attempt 0 backoff in [%s, %s), got %s", backoffBase, backoffBase*2, d)
+	}
+	if d := backoffWithJitter(20); d < backoffCap || d >= backoffCap+backoffBase {
+		t.Errorf("expected a high attempt count to cap around %s, got %s", backoffCap, d)
+	}
+}
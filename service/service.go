@@ -0,0 +1,29 @@
+// Package service contains small HTTP response helpers shared by every
+// service/* handler.
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func respondWithJSON(w http.ResponseWriter, httpStatusCode int, respModel interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatusCode)
+	if err := json.NewEncoder(w).Encode(respModel); err != nil {
+		log.Printf(" [!] Exception: service: failed to encode JSON response: %s", err)
+	}
+}
+
+// RespondWithSuccess responds with HTTP 200 and the given response model
+// marshaled as JSON.
+func RespondWithSuccess(w http.ResponseWriter, respModel interface{}) {
+	respondWithJSON(w, http.StatusOK, respModel)
+}
+
+// RespondWithErrorJSON responds with the given HTTP status code and the
+// given response model marshaled as JSON.
+func RespondWithErrorJSON(w http.ResponseWriter, httpStatusCode int, respModel interface{}) {
+	respondWithJSON(w, httpStatusCode, respModel)
+}